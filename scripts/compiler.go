@@ -0,0 +1,319 @@
+package scripts
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"strconv"
+	"strings"
+)
+
+// opcodeBytes maps the opcode mnemonics this package supports to their
+// single-byte wire encoding, following the assignments from the Bitcoin
+// protocol's original script.h opcode list (later formalized in BIP62,
+// BIP65 and BIP112).
+var opcodeBytes = map[string]byte{
+	"OP_FALSE":               0x00,
+	"OP_PUSHDATA1":           0x4c,
+	"OP_PUSHDATA2":           0x4d,
+	"OP_PUSHDATA4":           0x4e,
+	"OP_1NEGATE":             0x4f,
+	"OP_TRUE":                0x51,
+	"OP_NOP":                 0x61,
+	"OP_IF":                  0x63,
+	"OP_NOTIF":               0x64,
+	"OP_ELSE":                0x67,
+	"OP_ENDIF":               0x68,
+	"OP_VERIFY":              0x69,
+	"OP_RETURN":              0x6a,
+	"OP_DROP":                0x75,
+	"OP_DUP":                 0x76,
+	"OP_EQUAL":               0x87,
+	"OP_EQUALVERIFY":         0x88,
+	"OP_NOT":                 0x91,
+	"OP_ADD":                 0x93,
+	"OP_SUB":                 0x94,
+	"OP_BOOLAND":             0x9a,
+	"OP_BOOLOR":              0x9b,
+	"OP_RIPEMD160":           0xa6,
+	"OP_SHA1":                0xa7,
+	"OP_SHA256":              0xa8,
+	"OP_HASH160":             0xa9,
+	"OP_HASH256":             0xaa,
+	"OP_CHECKSIG":            0xac,
+	"OP_CHECKSIGVERIFY":      0xad,
+	"OP_CHECKMULTISIG":       0xae,
+	"OP_CHECKMULTISIGVERIFY": 0xaf,
+	"OP_NOP1":                0xb0,
+	"OP_CHECKLOCKTIMEVERIFY": 0xb1,
+	"OP_CHECKSEQUENCEVERIFY": 0xb2,
+	"OP_NOP4":                0xb3,
+	"OP_NOP5":                0xb4,
+	"OP_NOP6":                0xb5,
+	"OP_NOP7":                0xb6,
+	"OP_NOP8":                0xb7,
+	"OP_NOP9":                0xb8,
+	"OP_NOP10":               0xb9,
+}
+
+// byteToOpcode is the reverse of opcodeBytes, used by Disassemble.
+var byteToOpcode = func() map[byte]string {
+	m := make(map[byte]string, len(opcodeBytes))
+	for mnemonic, b := range opcodeBytes {
+		m[b] = mnemonic
+	}
+
+	return m
+}()
+
+// Compile converts an asm string written in this package's space-delimited
+// DSL into the byte-encoded script used on the wire: OP_* mnemonics become
+// their single opcode byte, hex-encoded values become data pushes
+// (OP_PUSHBYTES_N or OP_PUSHDATA1/2/4 depending on their length), and
+// decimal numbers are minimally encoded the way Bitcoin Core's CScriptNum
+// would serialize them.
+func Compile(asm string) ([]byte, error) {
+	var out []byte
+
+	for _, tok := range strings.Fields(asm) {
+		if isOpCode(tok) {
+			b, ok := opcodeBytes[tok]
+			if !ok {
+				return nil, newErrorf(ErrUnknownOpcode, "unsupported opcode: %s", tok)
+			}
+
+			out = append(out, b)
+			continue
+		}
+
+		data, err := compileData(tok)
+		if err != nil {
+			return nil, err
+		}
+
+		out = append(out, data...)
+	}
+
+	return out, nil
+}
+
+func compileData(tok string) ([]byte, error) {
+	if looksLikeNumber(tok) {
+		if n, err := strconv.ParseInt(tok, 10, 64); err == nil {
+			switch {
+			case n == 0:
+				return []byte{0x00}, nil
+			case n == -1:
+				return []byte{0x4f}, nil
+			case n >= 1 && n <= 16:
+				return []byte{byte(0x50 + n)}, nil
+			default:
+				return pushBytes(encodeScriptNum(n)), nil
+			}
+		}
+	}
+
+	data, err := hex.DecodeString(tok)
+	if err != nil {
+		return nil, newErrorf(ErrMalformedPush, "%q isn't a valid data push: %v", tok, err)
+	}
+
+	return pushBytes(data), nil
+}
+
+// looksLikeNumber reports whether tok should be compiled as a decimal
+// scriptnum literal rather than a hex data push. Both dialects use the
+// same plain-digit alphabet, so only a minimally-encoded integer (no
+// superfluous leading zero, as isMinimallyEncoded already requires of
+// numeric pushes elsewhere in this package) is treated as one: an
+// all-digit token that isn't minimally encoded, like a hex-encoded hash
+// that happens to contain only decimal digits (e.g. a run of zero bytes),
+// is compiled as a hex data push instead.
+func looksLikeNumber(tok string) bool {
+	digits := strings.TrimPrefix(tok, "-")
+	if digits == "" {
+		return false
+	}
+
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+
+	return isMinimallyEncoded(tok)
+}
+
+// pushBytes wraps data with the opcode that pushes it onto the stack,
+// picking the shortest encoding that fits its length.
+func pushBytes(data []byte) []byte {
+	n := len(data)
+
+	switch {
+	case n == 0:
+		return []byte{0x00}
+	case n < 0x4c:
+		return append([]byte{byte(n)}, data...)
+	case n <= 0xff:
+		return append([]byte{0x4c, byte(n)}, data...)
+	case n <= 0xffff:
+		header := make([]byte, 3)
+		header[0] = 0x4d
+		binary.LittleEndian.PutUint16(header[1:], uint16(n))
+		return append(header, data...)
+	default:
+		header := make([]byte, 5)
+		header[0] = 0x4e
+		binary.LittleEndian.PutUint32(header[1:], uint32(n))
+		return append(header, data...)
+	}
+}
+
+// encodeScriptNum serializes n the way Bitcoin Core's CScriptNum does:
+// little-endian signed magnitude, using the fewest bytes possible.
+func encodeScriptNum(n int64) []byte {
+	if n == 0 {
+		return nil
+	}
+
+	neg := n < 0
+
+	abs := uint64(n)
+	if neg {
+		abs = uint64(-n)
+	}
+
+	var result []byte
+	for abs > 0 {
+		result = append(result, byte(abs&0xff))
+		abs >>= 8
+	}
+
+	if result[len(result)-1]&0x80 != 0 {
+		if neg {
+			result = append(result, 0x80)
+		} else {
+			result = append(result, 0x00)
+		}
+	} else if neg {
+		result[len(result)-1] |= 0x80
+	}
+
+	return result
+}
+
+// decodeScriptNum parses the little-endian signed-magnitude encoding that
+// encodeScriptNum produces. It's the inverse PopInt/PeekInt fall back to
+// when a stack value isn't a plain decimal literal: once a number outside
+// -1..16 has been compiled to bytes and disassembled back, Disassemble
+// can't tell it apart from arbitrary data and renders it as a hex string
+// rather than its original decimal form.
+func decodeScriptNum(data []byte) int64 {
+	if len(data) == 0 {
+		return 0
+	}
+
+	var result int64
+	for i, b := range data {
+		result |= int64(b) << uint(8*i)
+	}
+
+	if data[len(data)-1]&0x80 != 0 {
+		result &^= int64(0x80) << uint(8*(len(data)-1))
+		return -result
+	}
+
+	return result
+}
+
+// Disassemble converts a byte-encoded script back into this package's
+// space-delimited DSL. Numeric data pushes round-trip as hex rather than
+// decimal: once encoded, a push is indistinguishable from arbitrary data.
+// PopInt/PeekInt still make sense of them, falling back to decodeScriptNum
+// when a value isn't a plain decimal literal.
+func Disassemble(b []byte) (string, error) {
+	var tokens []string
+
+	for i := 0; i < len(b); {
+		op := b[i]
+
+		switch {
+		case op == 0x00:
+			tokens = append(tokens, "0")
+			i++
+		case op == 0x4f:
+			tokens = append(tokens, "-1")
+			i++
+		case op >= 0x51 && op <= 0x60:
+			tokens = append(tokens, strconv.Itoa(int(op-0x50)))
+			i++
+		case op >= 0x01 && op <= 0x4b:
+			n := int(op)
+			i++
+
+			if i+n > len(b) {
+				return "", newError(ErrMalformedPush, "truncated data push")
+			}
+
+			tokens = append(tokens, hex.EncodeToString(b[i:i+n]))
+			i += n
+		case op == 0x4c || op == 0x4d || op == 0x4e:
+			i++
+
+			n, err := readPushLength(b, &i, op)
+			if err != nil {
+				return "", err
+			}
+
+			if i+n > len(b) {
+				return "", newError(ErrMalformedPush, "truncated data push")
+			}
+
+			tokens = append(tokens, hex.EncodeToString(b[i:i+n]))
+			i += n
+		default:
+			mnemonic, ok := byteToOpcode[op]
+			if !ok {
+				return "", newErrorf(ErrUnknownOpcode, "unsupported opcode byte: 0x%02x", op)
+			}
+
+			tokens = append(tokens, mnemonic)
+			i++
+		}
+	}
+
+	return strings.Join(tokens, " "), nil
+}
+
+// readPushLength reads the length prefix of an OP_PUSHDATA1/2/4 push,
+// advancing i past it.
+func readPushLength(b []byte, i *int, op byte) (int, error) {
+	switch op {
+	case 0x4c:
+		if *i+1 > len(b) {
+			return 0, newError(ErrMalformedPush, "truncated OP_PUSHDATA1 length")
+		}
+
+		n := int(b[*i])
+		*i++
+
+		return n, nil
+	case 0x4d:
+		if *i+2 > len(b) {
+			return 0, newError(ErrMalformedPush, "truncated OP_PUSHDATA2 length")
+		}
+
+		n := int(binary.LittleEndian.Uint16(b[*i : *i+2]))
+		*i += 2
+
+		return n, nil
+	default:
+		if *i+4 > len(b) {
+			return 0, newError(ErrMalformedPush, "truncated OP_PUSHDATA4 length")
+		}
+
+		n := int(binary.LittleEndian.Uint32(b[*i : *i+4]))
+		*i += 4
+
+		return n, nil
+	}
+}
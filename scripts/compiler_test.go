@@ -0,0 +1,81 @@
+package scripts_test
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/t-bast/btcutil/scripts"
+)
+
+func TestCompile(t *testing.T) {
+	type testCase struct {
+		name     string
+		asm      string
+		expected string
+	}
+
+	testCases := []testCase{
+		{"empty script", "", ""},
+		{"small int pushes", "0 -1 1 16", "004f5160"},
+		{"opcode sequence", "OP_DUP OP_HASH160 OP_EQUALVERIFY OP_CHECKSIG", "76a988ac"},
+		{"direct data push", "ab", "01ab"},
+		{"pushdata1", hex.EncodeToString(make([]byte, 80)), "4c50" + hex.EncodeToString(make([]byte, 80))},
+		{"number above the small-int range", "17", "0111"},
+		{"negative number above the small-int range", "-17", "0191"},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := scripts.Compile(tt.asm)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, hex.EncodeToString(b))
+		})
+	}
+
+	t.Run("unknown opcode", func(t *testing.T) {
+		_, err := scripts.Compile("OP_NOTANOPCODE")
+		assert.True(t, scripts.IsErrorCode(err, scripts.ErrUnknownOpcode))
+	})
+
+	t.Run("invalid hex data push", func(t *testing.T) {
+		_, err := scripts.Compile("not-hex")
+		assert.True(t, scripts.IsErrorCode(err, scripts.ErrMalformedPush))
+	})
+}
+
+func TestDisassemble(t *testing.T) {
+	t.Run("truncated data push", func(t *testing.T) {
+		_, err := scripts.Disassemble([]byte{0x02, 0xab})
+		assert.True(t, scripts.IsErrorCode(err, scripts.ErrMalformedPush))
+	})
+
+	t.Run("unknown opcode byte", func(t *testing.T) {
+		_, err := scripts.Disassemble([]byte{0xfe})
+		assert.True(t, scripts.IsErrorCode(err, scripts.ErrUnknownOpcode))
+	})
+
+	t.Run("round-trips through Compile", func(t *testing.T) {
+		asms := []string{
+			"0 -1 1 16",
+			"OP_DUP OP_HASH160 OP_EQUALVERIFY OP_CHECKSIG",
+			"ab cd",
+			"500000000",
+			hex.EncodeToString(make([]byte, 80)),
+		}
+
+		for _, asm := range asms {
+			compiled, err := scripts.Compile(asm)
+			require.NoError(t, err)
+
+			disassembled, err := scripts.Disassemble(compiled)
+			require.NoError(t, err)
+
+			recompiled, err := scripts.Compile(disassembled)
+			require.NoError(t, err)
+
+			assert.Equal(t, compiled, recompiled)
+		}
+	})
+}
@@ -0,0 +1,126 @@
+package scripts
+
+import "fmt"
+
+// ErrorCode identifies a class of script execution failure, following the
+// pattern used by btcd's txscript package.
+type ErrorCode int
+
+const (
+	// ErrStackUnderflow indicates that an opcode required more values on
+	// the stack than were available.
+	ErrStackUnderflow ErrorCode = iota
+
+	// ErrUnknownOpcode indicates that a script referenced an opcode that
+	// this package doesn't know how to execute.
+	ErrUnknownOpcode
+
+	// ErrEarlyReturn indicates that OP_RETURN was executed, which always
+	// halts script execution.
+	ErrEarlyReturn
+
+	// ErrVerifyFailed indicates that a verification opcode (OP_VERIFY,
+	// OP_EQUALVERIFY, ...) popped a value that didn't satisfy the check,
+	// or that the script evaluated to false.
+	ErrVerifyFailed
+
+	// ErrInvalidSignature indicates that a signature or public key could
+	// not be parsed, or that signature verification failed.
+	ErrInvalidSignature
+
+	// ErrMalformedPush indicates that a stack value couldn't be decoded
+	// in the format an opcode expected (e.g. not valid hex, or not a
+	// number), that a byte-encoded data push is truncated, or that a script
+	// that must only push data (a P2SH unlock script) contains an opcode.
+	ErrMalformedPush
+
+	// ErrUnbalancedConditional indicates that an OP_IF/OP_NOTIF wasn't
+	// matched by a corresponding OP_ENDIF, or that an OP_ELSE/OP_ENDIF
+	// appeared without a matching OP_IF/OP_NOTIF.
+	ErrUnbalancedConditional
+
+	// ErrCleanStack indicates that BIP62's clean stack rule was violated:
+	// more than one value remained on the stack after execution.
+	ErrCleanStack
+
+	// ErrDiscourageUpgradableNops indicates that ScriptDiscourageUpgradableNops
+	// is set and the script used one of the reserved OP_NOP1-OP_NOP10
+	// opcodes.
+	ErrDiscourageUpgradableNops
+
+	// ErrMinimalData indicates that ScriptVerifyMinimalData is set and the
+	// script pushed a numeric value that isn't minimally encoded.
+	ErrMinimalData
+
+	// ErrNullDummy indicates that ScriptStrictMultiSig is set and the
+	// dummy value consumed by OP_CHECKMULTISIG wasn't empty.
+	ErrNullDummy
+
+	// ErrNegativeLockTime indicates that OP_CHECKLOCKTIMEVERIFY or
+	// OP_CHECKSEQUENCEVERIFY peeked a negative value off the stack.
+	ErrNegativeLockTime
+
+	// ErrUnsatisfiedLockTime indicates that OP_CHECKLOCKTIMEVERIFY or
+	// OP_CHECKSEQUENCEVERIFY's requirement wasn't met by the transaction's
+	// locktime, version, or the input's sequence.
+	ErrUnsatisfiedLockTime
+)
+
+var errorCodeNames = map[ErrorCode]string{
+	ErrStackUnderflow:           "ErrStackUnderflow",
+	ErrUnknownOpcode:            "ErrUnknownOpcode",
+	ErrEarlyReturn:              "ErrEarlyReturn",
+	ErrVerifyFailed:             "ErrVerifyFailed",
+	ErrInvalidSignature:         "ErrInvalidSignature",
+	ErrMalformedPush:            "ErrMalformedPush",
+	ErrUnbalancedConditional:    "ErrUnbalancedConditional",
+	ErrCleanStack:               "ErrCleanStack",
+	ErrDiscourageUpgradableNops: "ErrDiscourageUpgradableNops",
+	ErrMinimalData:              "ErrMinimalData",
+	ErrNullDummy:                "ErrNullDummy",
+	ErrNegativeLockTime:         "ErrNegativeLockTime",
+	ErrUnsatisfiedLockTime:      "ErrUnsatisfiedLockTime",
+}
+
+// String returns the ErrorCode as a human-readable name.
+func (e ErrorCode) String() string {
+	if name, ok := errorCodeNames[e]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("ErrorCode(%d)", int(e))
+}
+
+// Error identifies a script execution failure with a machine-readable code
+// in addition to a human-readable description.
+type Error struct {
+	ErrorCode   ErrorCode
+	Description string
+}
+
+// Error satisfies the error interface.
+func (e Error) Error() string {
+	return e.Description
+}
+
+// newError creates an Error with the given code and description.
+func newError(c ErrorCode, desc string) Error {
+	return Error{ErrorCode: c, Description: desc}
+}
+
+// newErrorf creates an Error with the given code and a formatted
+// description.
+func newErrorf(c ErrorCode, format string, args ...interface{}) Error {
+	return newError(c, fmt.Sprintf(format, args...))
+}
+
+// IsErrorCode reports whether err is a scripts.Error carrying the given
+// code.
+func IsErrorCode(err error, code ErrorCode) bool {
+	serr, ok := err.(Error)
+	if !ok {
+		return false
+	}
+
+	return serr.ErrorCode == code
+}
@@ -0,0 +1,47 @@
+package scripts_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/t-bast/btcutil/scripts"
+)
+
+func TestErrors(t *testing.T) {
+	t.Run("IsErrorCode()", func(t *testing.T) {
+		t.Run("Stack underflow", func(t *testing.T) {
+			i, _ := scripts.NewTxInputInterpreter().
+				WithLockScript("OP_ADD").
+				WithUnlockScript("1").
+				Validate()
+
+			err := i.Execute()
+			assert.True(t, scripts.IsErrorCode(err, scripts.ErrStackUnderflow))
+			assert.False(t, scripts.IsErrorCode(err, scripts.ErrVerifyFailed))
+		})
+
+		t.Run("Unbalanced conditional", func(t *testing.T) {
+			i, _ := scripts.NewTxInputInterpreter().
+				WithLockScript("OP_IF OP_TRUE").
+				WithUnlockScript("1").
+				Validate()
+
+			err := i.Execute()
+			assert.True(t, scripts.IsErrorCode(err, scripts.ErrUnbalancedConditional))
+		})
+
+		t.Run("Verify failed", func(t *testing.T) {
+			i, _ := scripts.NewTxInputInterpreter().
+				WithLockScript("OP_EQUAL").
+				WithUnlockScript("1 2").
+				Validate()
+
+			err := i.Execute()
+			assert.True(t, scripts.IsErrorCode(err, scripts.ErrVerifyFailed))
+		})
+
+		t.Run("Not a scripts.Error", func(t *testing.T) {
+			assert.False(t, scripts.IsErrorCode(nil, scripts.ErrVerifyFailed))
+		})
+	})
+}
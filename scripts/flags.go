@@ -0,0 +1,38 @@
+package scripts
+
+// ScriptFlags is a bitmask of individual flags that modify the way scripts
+// are executed, following the conventions used by btcd's txscript package.
+type ScriptFlags uint32
+
+const (
+	// ScriptBip16 defines whether the BIP16 (Pay-to-Script-Hash) rules
+	// should be enforced.
+	ScriptBip16 ScriptFlags = 1 << iota
+
+	// ScriptVerifyCleanStack defines whether BIP62 rule 6 is enforced:
+	// exactly one value must remain on the stack after execution. Without
+	// this flag, only the top stack value needs to be truthy.
+	ScriptVerifyCleanStack
+
+	// ScriptStrictMultiSig defines whether the dummy value popped by
+	// OP_CHECKMULTISIG (due to an off-by-one bug in the original client
+	// that scripts now rely on) must be empty.
+	ScriptStrictMultiSig
+
+	// ScriptDiscourageUpgradableNops defines whether execution should
+	// fail when OP_NOP1 or OP_NOP4 through OP_NOP10 are used (OP_NOP2 and
+	// OP_NOP3 are now OP_CHECKLOCKTIMEVERIFY and OP_CHECKSEQUENCEVERIFY,
+	// which this flag doesn't affect), since they're reserved for future
+	// soft forks and a script relying on their current no-op
+	// behavior may not mean what the author intended.
+	ScriptDiscourageUpgradableNops
+
+	// ScriptVerifyMinimalData defines whether numeric values pushed onto
+	// the stack must use the shortest possible encoding.
+	ScriptVerifyMinimalData
+)
+
+// HasFlag reports whether the given flag is set.
+func (f ScriptFlags) HasFlag(flag ScriptFlags) bool {
+	return f&flag == flag
+}
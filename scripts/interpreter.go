@@ -1,11 +1,17 @@
 package scripts
 
-import "strings"
+import (
+	"encoding/hex"
+	"strings"
+)
 
 // Interpreter for a Bitcoin script.
 type Interpreter interface {
 	// Evaluate the script.
 	Evaluate() bool
+
+	// Execute the script, returning the reason it failed, if any.
+	Execute() error
 }
 
 // TxInputInterpreter interprets a transaction's unlock script.
@@ -13,6 +19,11 @@ type TxInputInterpreter struct {
 	signedBytes  []byte
 	lockScript   []string
 	unlockScript []string
+	flags        ScriptFlags
+
+	txLockTime    uint32
+	inputSequence uint32
+	txVersion     int32
 }
 
 // NewTxInputInterpreter creates an interpreter for a transaction input.
@@ -41,6 +52,55 @@ func (i *TxInputInterpreter) WithSignedBytes(b []byte) *TxInputInterpreter {
 	return i
 }
 
+// WithFlags sets the ScriptFlags that control the execution mode.
+func (i *TxInputInterpreter) WithFlags(flags ScriptFlags) *TxInputInterpreter {
+	i.flags = flags
+	return i
+}
+
+// WithTxLockTime sets the transaction's locktime, checked by
+// OP_CHECKLOCKTIMEVERIFY.
+func (i *TxInputInterpreter) WithTxLockTime(lockTime uint32) *TxInputInterpreter {
+	i.txLockTime = lockTime
+	return i
+}
+
+// WithInputSequence sets the sequence number of the input being verified,
+// checked by OP_CHECKLOCKTIMEVERIFY and OP_CHECKSEQUENCEVERIFY.
+func (i *TxInputInterpreter) WithInputSequence(sequence uint32) *TxInputInterpreter {
+	i.inputSequence = sequence
+	return i
+}
+
+// WithTxVersion sets the transaction's version, checked by
+// OP_CHECKSEQUENCEVERIFY.
+func (i *TxInputInterpreter) WithTxVersion(version int32) *TxInputInterpreter {
+	i.txVersion = version
+	return i
+}
+
+// WithLockScriptBytes sets the locking script (pubKeyScript) from its raw
+// wire encoding, disassembling it into this package's DSL.
+func (i *TxInputInterpreter) WithLockScriptBytes(b []byte) (*TxInputInterpreter, error) {
+	asm, err := Disassemble(b)
+	if err != nil {
+		return i, err
+	}
+
+	return i.WithLockScript(asm), nil
+}
+
+// WithUnlockScriptBytes sets the unlocking script (sigScript) from its raw
+// wire encoding, disassembling it into this package's DSL.
+func (i *TxInputInterpreter) WithUnlockScriptBytes(b []byte) (*TxInputInterpreter, error) {
+	asm, err := Disassemble(b)
+	if err != nil {
+		return i, err
+	}
+
+	return i.WithUnlockScript(asm), nil
+}
+
 // Validate the script (without evaluating it).
 // You can use the returned Interpreter to actually evaluate the script.
 func (i *TxInputInterpreter) Validate() (Interpreter, error) {
@@ -50,11 +110,84 @@ func (i *TxInputInterpreter) Validate() (Interpreter, error) {
 
 // Evaluate the script.
 func (i *TxInputInterpreter) Evaluate() bool {
-	stack := InitStack().WithTxBytes(i.signedBytes)
-	ok := stack.ExecuteUnlock(i.unlockScript)
-	if !ok {
+	return i.Execute() == nil
+}
+
+// newStack builds the Stack that evaluation starts from, carrying over the
+// transaction context the interpreter was given.
+func (i *TxInputInterpreter) newStack() *Stack {
+	return InitStack().
+		WithTxBytes(i.signedBytes).
+		WithFlags(i.flags).
+		WithTxLockTime(i.txLockTime).
+		WithInputSequence(i.inputSequence).
+		WithTxVersion(i.txVersion)
+}
+
+// Execute the script, returning the reason it failed, if any.
+func (i *TxInputInterpreter) Execute() error {
+	stack := i.newStack()
+
+	if err := stack.ExecuteUnlockErr(i.unlockScript); err != nil {
+		return err
+	}
+
+	// Keep a copy of the stack left by the unlock script: if this turns out
+	// to be a P2SH input, the redeem script must see it again once the
+	// OP_HASH160/OP_EQUAL check below has consumed it.
+	unlockStack := stack.Print()
+
+	if err := stack.ExecuteErr(i.lockScript); err != nil {
+		return err
+	}
+
+	if !i.flags.HasFlag(ScriptBip16) || !isP2SH(i.lockScript) {
+		return nil
+	}
+
+	return i.executeRedeemScript(unlockStack)
+}
+
+// isP2SH reports whether lockScript is the BIP16 template:
+// OP_HASH160 <20-byte hash> OP_EQUAL.
+func isP2SH(lockScript []string) bool {
+	if len(lockScript) != 3 || lockScript[0] != "OP_HASH160" || lockScript[2] != "OP_EQUAL" {
 		return false
 	}
 
-	return stack.Execute(i.lockScript)
+	h, err := hex.DecodeString(lockScript[1])
+	return err == nil && len(h) == 20
+}
+
+// executeRedeemScript implements the second stage of BIP16 evaluation: the
+// unlock script must be push-only, and its last pushed value is the
+// serialized redeem script. Its hash has already been checked against the
+// lock script by the initial evaluation, so it's disassembled here and
+// executed against whatever the unlock script left below it.
+func (i *TxInputInterpreter) executeRedeemScript(unlockStack []string) error {
+	for _, val := range i.unlockScript {
+		if isOpCode(val) {
+			return newError(ErrMalformedPush, "P2SH unlock script must only push data")
+		}
+	}
+
+	redeemScriptBytes, err := hex.DecodeString(unlockStack[len(unlockStack)-1])
+	if err != nil {
+		return newErrorf(ErrMalformedPush, "redeem script should be a hex string: %v", err)
+	}
+
+	redeemScriptAsm, err := Disassemble(redeemScriptBytes)
+	if err != nil {
+		return err
+	}
+
+	var redeemScript []string
+	if redeemScriptAsm != "" {
+		redeemScript = strings.Split(redeemScriptAsm, " ")
+	}
+
+	stack := i.newStack()
+	stack.values = append(stack.values, unlockStack[:len(unlockStack)-1]...)
+
+	return stack.ExecuteErr(redeemScript)
 }
@@ -14,6 +14,21 @@ import (
 	"github.com/t-bast/btcutil/scripts"
 )
 
+// compileRedeemScript compiles a redeem script asm and returns both its
+// serialized hex encoding (as pushed by a P2SH unlock script) and the
+// OP_HASH160 digest a matching P2SH lock script expects.
+func compileRedeemScript(t *testing.T, asm string) (string, string) {
+	b, err := scripts.Compile(asm)
+	require.NoError(t, err)
+
+	h := sha256.Sum256(b)
+
+	r := ripemd160.New()
+	r.Write(h[:])
+
+	return hex.EncodeToString(b), hex.EncodeToString(r.Sum(nil))
+}
+
 func createSig(t *testing.T, tx []byte) (string, string, string) {
 	privKey, err := btcec.NewPrivateKey(btcec.S256())
 	require.NoError(t, err)
@@ -22,8 +37,10 @@ func createSig(t *testing.T, tx []byte) (string, string, string) {
 	hexPubKey := hex.EncodeToString(pubKey.SerializeCompressed())
 
 	h := sha256.Sum256(pubKey.SerializeCompressed())
-	pubKeyHash := ripemd160.New().Sum(h[:])
-	hexPubKeyHash := hex.EncodeToString(pubKeyHash)
+
+	r := ripemd160.New()
+	r.Write(h[:])
+	hexPubKeyHash := hex.EncodeToString(r.Sum(nil))
 
 	sig, err := privKey.Sign(tx)
 	require.NoError(t, err)
@@ -103,7 +120,7 @@ func TestInterpreter(t *testing.T) {
 
 			i, _ := scripts.NewTxInputInterpreter().
 				WithLockScript(fmt.Sprintf("2 %s %s %s 3 OP_CHECKMULTISIG", pk1, pk2, pk3)).
-				WithUnlockScript(fmt.Sprintf("%s %s", sig3, sig1)).
+				WithUnlockScript(fmt.Sprintf("0 %s %s", sig3, sig1)).
 				WithSignedBytes(tx).
 				Validate()
 
@@ -136,12 +153,261 @@ func TestInterpreter(t *testing.T) {
 
 			i, _ := scripts.NewTxInputInterpreter().
 				WithLockScript(fmt.Sprintf("2 %s %s %s 3 OP_CHECKMULTISIG", pk1, pk2, pk3)).
-				WithUnlockScript(fmt.Sprintf("%s %s", sig1, sig4)).
+				WithUnlockScript(fmt.Sprintf("0 %s %s", sig1, sig4)).
+				WithSignedBytes(tx).
+				Validate()
+
+			ok := i.Evaluate()
+			assert.False(t, ok)
+		})
+
+		t.Run("Valid multisig non-empty dummy accepted without ScriptStrictMultiSig", func(t *testing.T) {
+			tx := []byte{42}
+			_, pk1, sig1 := createSig(t, tx)
+			_, pk2, _ := createSig(t, tx)
+			_, pk3, sig3 := createSig(t, tx)
+
+			i, _ := scripts.NewTxInputInterpreter().
+				WithLockScript(fmt.Sprintf("2 %s %s %s 3 OP_CHECKMULTISIG", pk1, pk2, pk3)).
+				WithUnlockScript(fmt.Sprintf("1 %s %s", sig3, sig1)).
+				WithSignedBytes(tx).
+				Validate()
+
+			ok := i.Evaluate()
+			assert.True(t, ok)
+		})
+
+		t.Run("Invalid multisig non-empty dummy rejected with ScriptStrictMultiSig", func(t *testing.T) {
+			tx := []byte{42}
+			_, pk1, sig1 := createSig(t, tx)
+			_, pk2, _ := createSig(t, tx)
+			_, pk3, sig3 := createSig(t, tx)
+
+			i, _ := scripts.NewTxInputInterpreter().
+				WithLockScript(fmt.Sprintf("2 %s %s %s 3 OP_CHECKMULTISIG", pk1, pk2, pk3)).
+				WithUnlockScript(fmt.Sprintf("1 %s %s", sig3, sig1)).
+				WithSignedBytes(tx).
+				WithFlags(scripts.ScriptStrictMultiSig).
+				Validate()
+
+			err := i.Execute()
+			assert.True(t, scripts.IsErrorCode(err, scripts.ErrNullDummy))
+		})
+
+		t.Run("Valid HTLC-style conditional redeem path", func(t *testing.T) {
+			tx := []byte{42}
+			pubKeyHash, pubKey, sig := createSig(t, tx)
+
+			i, _ := scripts.NewTxInputInterpreter().
+				WithLockScript(fmt.Sprintf(
+					"OP_IF OP_DUP OP_HASH160 %s OP_EQUALVERIFY OP_CHECKSIG OP_ELSE OP_FALSE OP_ENDIF",
+					pubKeyHash,
+				)).
+				WithUnlockScript(fmt.Sprintf("%s %s 1", sig, pubKey)).
+				WithSignedBytes(tx).
+				Validate()
+
+			ok := i.Evaluate()
+			assert.True(t, ok)
+		})
+
+		t.Run("Invalid HTLC-style conditional refund path", func(t *testing.T) {
+			tx := []byte{42}
+			pubKeyHash, pubKey, sig := createSig(t, tx)
+
+			i, _ := scripts.NewTxInputInterpreter().
+				WithLockScript(fmt.Sprintf(
+					"OP_IF OP_DUP OP_HASH160 %s OP_EQUALVERIFY OP_CHECKSIG OP_ELSE OP_FALSE OP_ENDIF",
+					pubKeyHash,
+				)).
+				WithUnlockScript(fmt.Sprintf("%s %s 0", sig, pubKey)).
+				WithSignedBytes(tx).
+				Validate()
+
+			ok := i.Evaluate()
+			assert.False(t, ok)
+		})
+
+		t.Run("Valid P2SH-wrapped multisig", func(t *testing.T) {
+			tx := []byte{42}
+			_, pk1, sig1 := createSig(t, tx)
+			_, pk2, _ := createSig(t, tx)
+			_, pk3, sig3 := createSig(t, tx)
+
+			redeemScriptHex, redeemScriptHash := compileRedeemScript(
+				t, fmt.Sprintf("2 %s %s %s 3 OP_CHECKMULTISIG", pk1, pk2, pk3),
+			)
+
+			i, _ := scripts.NewTxInputInterpreter().
+				WithLockScript(fmt.Sprintf("OP_HASH160 %s OP_EQUAL", redeemScriptHash)).
+				WithUnlockScript(fmt.Sprintf("0 %s %s %s", sig3, sig1, redeemScriptHex)).
+				WithSignedBytes(tx).
+				WithFlags(scripts.ScriptBip16).
+				Validate()
+
+			ok := i.Evaluate()
+			assert.True(t, ok)
+		})
+
+		t.Run("Invalid P2SH redeem script hash mismatch", func(t *testing.T) {
+			tx := []byte{42}
+			_, pk1, sig1 := createSig(t, tx)
+			_, pk2, _ := createSig(t, tx)
+			_, pk3, sig3 := createSig(t, tx)
+
+			redeemScriptHex, _ := compileRedeemScript(
+				t, fmt.Sprintf("2 %s %s %s 3 OP_CHECKMULTISIG", pk1, pk2, pk3),
+			)
+
+			i, _ := scripts.NewTxInputInterpreter().
+				WithLockScript("OP_HASH160 0000000000000000000000000000000000000000 OP_EQUAL").
+				WithUnlockScript(fmt.Sprintf("0 %s %s %s", sig3, sig1, redeemScriptHex)).
 				WithSignedBytes(tx).
+				WithFlags(scripts.ScriptBip16).
 				Validate()
 
 			ok := i.Evaluate()
 			assert.False(t, ok)
 		})
+
+		t.Run("Invalid P2SH non-push-only unlock script", func(t *testing.T) {
+			tx := []byte{42}
+			_, pk1, sig1 := createSig(t, tx)
+			_, pk2, _ := createSig(t, tx)
+			_, pk3, sig3 := createSig(t, tx)
+
+			redeemScriptHex, redeemScriptHash := compileRedeemScript(
+				t, fmt.Sprintf("2 %s %s %s 3 OP_CHECKMULTISIG", pk1, pk2, pk3),
+			)
+
+			i, _ := scripts.NewTxInputInterpreter().
+				WithLockScript(fmt.Sprintf("OP_HASH160 %s OP_EQUAL", redeemScriptHash)).
+				WithUnlockScript(fmt.Sprintf("0 %s %s %s OP_DUP OP_DROP", sig3, sig1, redeemScriptHex)).
+				WithSignedBytes(tx).
+				WithFlags(scripts.ScriptBip16).
+				Validate()
+
+			err := i.Execute()
+			assert.True(t, scripts.IsErrorCode(err, scripts.ErrMalformedPush))
+		})
+
+		t.Run("Valid P2SH-wrapped multisig from compiled script bytes", func(t *testing.T) {
+			tx := []byte{42}
+			_, pk1, sig1 := createSig(t, tx)
+			_, pk2, _ := createSig(t, tx)
+			_, pk3, sig3 := createSig(t, tx)
+
+			redeemScriptHex, redeemScriptHash := compileRedeemScript(
+				t, fmt.Sprintf("2 %s %s %s 3 OP_CHECKMULTISIG", pk1, pk2, pk3),
+			)
+
+			lockScriptBytes, err := scripts.Compile(fmt.Sprintf("OP_HASH160 %s OP_EQUAL", redeemScriptHash))
+			require.NoError(t, err)
+
+			unlockScriptBytes, err := scripts.Compile(fmt.Sprintf("0 %s %s %s", sig3, sig1, redeemScriptHex))
+			require.NoError(t, err)
+
+			i, err := scripts.NewTxInputInterpreter().
+				WithSignedBytes(tx).
+				WithFlags(scripts.ScriptBip16).
+				WithLockScriptBytes(lockScriptBytes)
+			require.NoError(t, err)
+
+			i, err = i.WithUnlockScriptBytes(unlockScriptBytes)
+			require.NoError(t, err)
+
+			interp, err := i.Validate()
+			require.NoError(t, err)
+
+			ok := interp.Evaluate()
+			assert.True(t, ok)
+		})
+
+		t.Run("Valid CLTV-gated redeem path", func(t *testing.T) {
+			tx := []byte{42}
+			pubKeyHash, pubKey, sig := createSig(t, tx)
+
+			i, _ := scripts.NewTxInputInterpreter().
+				WithLockScript(fmt.Sprintf(
+					"500000000 OP_CHECKLOCKTIMEVERIFY OP_DROP OP_DUP OP_HASH160 %s OP_EQUALVERIFY OP_CHECKSIG",
+					pubKeyHash,
+				)).
+				WithUnlockScript(fmt.Sprintf("%s %s", sig, pubKey)).
+				WithSignedBytes(tx).
+				WithTxLockTime(500000001).
+				WithInputSequence(0).
+				Validate()
+
+			ok := i.Evaluate()
+			assert.True(t, ok)
+		})
+
+		t.Run("Valid CLTV-gated redeem path from compiled script bytes", func(t *testing.T) {
+			tx := []byte{42}
+			pubKeyHash, pubKey, sig := createSig(t, tx)
+
+			lockScriptBytes, err := scripts.Compile(fmt.Sprintf(
+				"500000000 OP_CHECKLOCKTIMEVERIFY OP_DROP OP_DUP OP_HASH160 %s OP_EQUALVERIFY OP_CHECKSIG",
+				pubKeyHash,
+			))
+			require.NoError(t, err)
+
+			unlockScriptBytes, err := scripts.Compile(fmt.Sprintf("%s %s", sig, pubKey))
+			require.NoError(t, err)
+
+			i, err := scripts.NewTxInputInterpreter().
+				WithSignedBytes(tx).
+				WithTxLockTime(500000001).
+				WithInputSequence(0).
+				WithLockScriptBytes(lockScriptBytes)
+			require.NoError(t, err)
+
+			i, err = i.WithUnlockScriptBytes(unlockScriptBytes)
+			require.NoError(t, err)
+
+			interp, err := i.Validate()
+			require.NoError(t, err)
+
+			ok := interp.Evaluate()
+			assert.True(t, ok)
+		})
+
+		t.Run("Invalid CLTV-gated redeem path with a height-based tx locktime against a time lock", func(t *testing.T) {
+			tx := []byte{42}
+			pubKeyHash, pubKey, sig := createSig(t, tx)
+
+			i, _ := scripts.NewTxInputInterpreter().
+				WithLockScript(fmt.Sprintf(
+					"500000000 OP_CHECKLOCKTIMEVERIFY OP_DROP OP_DUP OP_HASH160 %s OP_EQUALVERIFY OP_CHECKSIG",
+					pubKeyHash,
+				)).
+				WithUnlockScript(fmt.Sprintf("%s %s", sig, pubKey)).
+				WithSignedBytes(tx).
+				WithTxLockTime(400000000).
+				WithInputSequence(0).
+				Validate()
+
+			err := i.Execute()
+			assert.True(t, scripts.IsErrorCode(err, scripts.ErrUnsatisfiedLockTime))
+		})
+
+		t.Run("Invalid CSV-gated redeem path on a v1 transaction", func(t *testing.T) {
+			tx := []byte{42}
+			pubKeyHash, pubKey, sig := createSig(t, tx)
+
+			i, _ := scripts.NewTxInputInterpreter().
+				WithLockScript(fmt.Sprintf(
+					"10 OP_CHECKSEQUENCEVERIFY OP_DROP OP_DUP OP_HASH160 %s OP_EQUALVERIFY OP_CHECKSIG",
+					pubKeyHash,
+				)).
+				WithUnlockScript(fmt.Sprintf("%s %s", sig, pubKey)).
+				WithSignedBytes(tx).
+				WithInputSequence(20).
+				WithTxVersion(1).
+				Validate()
+
+			err := i.Execute()
+			assert.True(t, scripts.IsErrorCode(err, scripts.ErrUnsatisfiedLockTime))
+		})
 	})
 }
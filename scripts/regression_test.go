@@ -0,0 +1,146 @@
+package scripts_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/t-bast/btcutil/scripts"
+)
+
+// regressionExpectations maps the expected_result strings used by this
+// fixture (borrowed from Bitcoin Core's script_tests.json vocabulary for
+// familiarity) to the scripts.ErrorCode this package reports for the same
+// failure. "OK" isn't in this map: it means evaluation must succeed. An
+// expected_result we don't have a specific code for still needs evaluation
+// to fail, it's just not checked against a precise code.
+var regressionExpectations = map[string]scripts.ErrorCode{
+	"EVAL_FALSE":                 scripts.ErrVerifyFailed,
+	"VERIFY":                     scripts.ErrVerifyFailed,
+	"UNBALANCED_CONDITIONAL":     scripts.ErrUnbalancedConditional,
+	"CLEANSTACK":                 scripts.ErrCleanStack,
+	"MINIMALDATA":                scripts.ErrMinimalData,
+	"DISCOURAGE_UPGRADABLE_NOPS": scripts.ErrDiscourageUpgradableNops,
+	"SIG_NULLDUMMY":              scripts.ErrNullDummy,
+	"BAD_OPCODE":                 scripts.ErrUnknownOpcode,
+	"SIG_PUSHONLY":               scripts.ErrMalformedPush,
+	"NEGATIVE_LOCKTIME":          scripts.ErrNegativeLockTime,
+	"UNSATISFIED_LOCKTIME":       scripts.ErrUnsatisfiedLockTime,
+}
+
+// regressionFlags maps the flag names used in the fixture to the
+// ScriptFlags this package currently implements. Flags this package doesn't
+// support yet (STRICTENC, DERSIG, LOW_S, SIGPUSHONLY, ...) are silently
+// ignored.
+var regressionFlags = map[string]scripts.ScriptFlags{
+	"P2SH":                       scripts.ScriptBip16,
+	"CLEANSTACK":                 scripts.ScriptVerifyCleanStack,
+	"NULLDUMMY":                  scripts.ScriptStrictMultiSig,
+	"DISCOURAGE_UPGRADABLE_NOPS": scripts.ScriptDiscourageUpgradableNops,
+	"MINIMALDATA":                scripts.ScriptVerifyMinimalData,
+}
+
+func parseRegressionFlags(s string) scripts.ScriptFlags {
+	var flags scripts.ScriptFlags
+
+	for _, tok := range strings.Split(s, ",") {
+		if flag, ok := regressionFlags[strings.TrimSpace(tok)]; ok {
+			flags |= flag
+		}
+	}
+
+	return flags
+}
+
+// regressionContext carries the optional transaction context
+// OP_CHECKLOCKTIMEVERIFY/OP_CHECKSEQUENCEVERIFY cases check against. Core's
+// script_tests.json instead embeds a full serialized transaction, which this
+// package doesn't parse, so locktime cases carry it as a trailing object
+// this fixture format adds on top of Core's row shape.
+type regressionContext struct {
+	TxLockTime    uint32 `json:"txLockTime"`
+	InputSequence uint32 `json:"inputSequence"`
+	TxVersion     int32  `json:"txVersion"`
+}
+
+// TestDSLRegressionScripts runs scripts/testdata/dsl_regression_scripts.json,
+// a hand-written regression fixture for this package's own script semantics.
+// It borrows the [witness?, scriptSig, scriptPubKey, flags, expected_result,
+// comment?] row shape and expected_result vocabulary from Bitcoin Core's
+// script_tests.json for familiarity, but every case here is authored by
+// hand, not sourced from Core, and scriptSig/scriptPubKey are written in this
+// package's own space-delimited DSL rather than Core's asm dialect. Passing
+// this test is not evidence of conformance with Core: it only pins down this
+// package's own behaviour against regressions. The leading witness array,
+// when present, is accepted and ignored since this package has no segwit
+// support. Rows with fewer than 4 fields are section-header comments and are
+// skipped.
+func TestDSLRegressionScripts(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "dsl_regression_scripts.json"))
+	require.NoError(t, err)
+
+	var rawCases [][]interface{}
+	require.NoError(t, json.Unmarshal(data, &rawCases))
+
+	for n, raw := range rawCases {
+		idx := 0
+		if _, isWitness := raw[0].([]interface{}); isWitness {
+			idx = 1
+		}
+
+		if len(raw)-idx < 4 {
+			continue
+		}
+
+		sigScript, _ := raw[idx].(string)
+		pubKeyScript, _ := raw[idx+1].(string)
+		flagsStr, _ := raw[idx+2].(string)
+		expected, _ := raw[idx+3].(string)
+
+		comment := fmt.Sprintf("case %d", n)
+		if len(raw)-idx > 4 {
+			if c, ok := raw[idx+4].(string); ok {
+				comment = c
+			}
+		}
+
+		var ctx regressionContext
+		if len(raw)-idx > 5 && raw[idx+5] != nil {
+			b, err := json.Marshal(raw[idx+5])
+			require.NoError(t, err)
+			require.NoError(t, json.Unmarshal(b, &ctx))
+		}
+
+		t.Run(comment, func(t *testing.T) {
+			i, err := scripts.NewTxInputInterpreter().
+				WithLockScript(pubKeyScript).
+				WithUnlockScript(sigScript).
+				WithFlags(parseRegressionFlags(flagsStr)).
+				WithTxLockTime(ctx.TxLockTime).
+				WithInputSequence(ctx.InputSequence).
+				WithTxVersion(ctx.TxVersion).
+				Validate()
+			require.NoError(t, err)
+
+			execErr := i.Execute()
+
+			if expected == "OK" {
+				assert.NoError(t, execErr)
+				return
+			}
+
+			if code, ok := regressionExpectations[expected]; ok {
+				assert.True(t, scripts.IsErrorCode(execErr, code),
+					"expected error code %s, got %v", code, execErr)
+				return
+			}
+
+			assert.Error(t, execErr)
+		})
+	}
+}
@@ -6,9 +6,9 @@ import (
 	"encoding/hex"
 	"fmt"
 	"strconv"
+	"strings"
 
 	"github.com/btcsuite/btcd/btcec"
-	"github.com/pkg/errors"
 
 	"golang.org/x/crypto/ripemd160"
 )
@@ -17,6 +17,16 @@ import (
 type Stack struct {
 	tx     []byte
 	values []string
+	flags  ScriptFlags
+	// conds tracks whether each currently open OP_IF/OP_NOTIF branch is
+	// active. The branch at the top of the script is currently executing
+	// only if every entry is true.
+	conds []bool
+	// txLockTime, inputSequence and txVersion give OP_CHECKLOCKTIMEVERIFY
+	// and OP_CHECKSEQUENCEVERIFY the transaction context they check against.
+	txLockTime    uint32
+	inputSequence uint32
+	txVersion     int32
 }
 
 // Operation applies transformations to the stack.
@@ -35,6 +45,33 @@ func (s *Stack) WithTxBytes(b []byte) *Stack {
 	return s
 }
 
+// WithFlags sets the ScriptFlags that control the execution mode.
+func (s *Stack) WithFlags(flags ScriptFlags) *Stack {
+	s.flags = flags
+	return s
+}
+
+// WithTxLockTime sets the transaction's locktime, checked by
+// OP_CHECKLOCKTIMEVERIFY.
+func (s *Stack) WithTxLockTime(lockTime uint32) *Stack {
+	s.txLockTime = lockTime
+	return s
+}
+
+// WithInputSequence sets the sequence number of the input being verified,
+// checked by OP_CHECKLOCKTIMEVERIFY and OP_CHECKSEQUENCEVERIFY.
+func (s *Stack) WithInputSequence(sequence uint32) *Stack {
+	s.inputSequence = sequence
+	return s
+}
+
+// WithTxVersion sets the transaction's version, checked by
+// OP_CHECKSEQUENCEVERIFY.
+func (s *Stack) WithTxVersion(version int32) *Stack {
+	s.txVersion = version
+	return s
+}
+
 // Pop one value from the stack.
 func (s *Stack) Pop() string {
 	n := len(s.values)
@@ -45,8 +82,36 @@ func (s *Stack) Pop() string {
 
 // PopInt pops an integer value from the stack.
 func (s *Stack) PopInt() (int64, error) {
-	v := s.Pop()
-	return strconv.ParseInt(v, 0, 0)
+	return parseScriptNum(s.Pop())
+}
+
+// Peek returns the top value of the stack without removing it.
+func (s *Stack) Peek() string {
+	return s.values[len(s.values)-1]
+}
+
+// PeekInt returns the top value of the stack, parsed as an integer, without
+// removing it.
+func (s *Stack) PeekInt() (int64, error) {
+	return parseScriptNum(s.Peek())
+}
+
+// parseScriptNum parses a stack value as a number: either the DSL's native
+// decimal literal form, or, failing that, the hex-encoded CScriptNum a
+// numeric push takes once it's round-tripped through Compile/Disassemble
+// (Disassemble can't distinguish a number from arbitrary data at the byte
+// level, so it always renders pushes outside -1..16 as hex).
+func parseScriptNum(val string) (int64, error) {
+	if n, err := strconv.ParseInt(val, 0, 0); err == nil {
+		return n, nil
+	}
+
+	data, err := hex.DecodeString(val)
+	if err != nil {
+		return 0, fmt.Errorf("%q isn't a valid scriptnum: not a decimal literal or hex string", val)
+	}
+
+	return decodeScriptNum(data), nil
 }
 
 // Push a value on the stack.
@@ -70,66 +135,199 @@ func (s *Stack) Print() []string {
 	return res
 }
 
+// branchActive reports whether the currently executing branch is active,
+// i.e. every enclosing OP_IF/OP_NOTIF condition evaluated to true.
+func (s *Stack) branchActive() bool {
+	for _, active := range s.conds {
+		if !active {
+			return false
+		}
+	}
+
+	return true
+}
+
 func (s *Stack) execute(script []string) error {
 	for _, val := range script {
 		if isOpCode(val) {
+			if isCondOpCode(val) {
+				if err := s.executeCond(val); err != nil {
+					return err
+				}
+
+				continue
+			}
+
+			if !s.branchActive() {
+				continue
+			}
+
 			op, ok := ops[val]
 			if !ok {
-				return fmt.Errorf("unsupported opcode: %s", val)
+				return newErrorf(ErrUnknownOpcode, "unsupported opcode: %s", val)
 			}
 
 			if err := op(s); err != nil {
-				return errors.Wrap(err, "operation failed")
+				return err
 			}
-		} else {
+		} else if s.branchActive() {
+			if s.flags.HasFlag(ScriptVerifyMinimalData) && !isMinimallyEncoded(val) {
+				return newErrorf(ErrMinimalData, "push of %q isn't minimally encoded", val)
+			}
+
 			s.values = append(s.values, val)
 		}
 	}
 
+	if len(s.conds) != 0 {
+		return newError(ErrUnbalancedConditional, "unbalanced conditional at end of script")
+	}
+
+	return nil
+}
+
+func isCondOpCode(val string) bool {
+	switch val {
+	case "OP_IF", "OP_NOTIF", "OP_ELSE", "OP_ENDIF":
+		return true
+	default:
+		return false
+	}
+}
+
+// executeCond applies a control-flow opcode, updating the conditional stack.
+// OP_IF/OP_NOTIF only consume a stack value while the enclosing branch is
+// active; when it isn't, they still push onto the conditional stack so that
+// nesting is tracked correctly.
+func (s *Stack) executeCond(val string) error {
+	switch val {
+	case "OP_IF", "OP_NOTIF":
+		if !s.branchActive() {
+			s.conds = append(s.conds, false)
+			return nil
+		}
+
+		if s.Size() < 1 {
+			return newErrorf(ErrStackUnderflow, "%s requires a value on the stack", val)
+		}
+
+		active := s.Pop() != "0"
+		if val == "OP_NOTIF" {
+			active = !active
+		}
+
+		s.conds = append(s.conds, active)
+	case "OP_ELSE":
+		if len(s.conds) == 0 {
+			return newError(ErrUnbalancedConditional, "OP_ELSE without matching OP_IF")
+		}
+
+		n := len(s.conds) - 1
+		s.conds[n] = !s.conds[n]
+	case "OP_ENDIF":
+		if len(s.conds) == 0 {
+			return newError(ErrUnbalancedConditional, "OP_ENDIF without matching OP_IF")
+		}
+
+		s.conds = s.conds[:len(s.conds)-1]
+	}
+
 	return nil
 }
 
 // Execute the given script and returns the outcome.
 func (s *Stack) Execute(script []string) bool {
+	return s.ExecuteErr(script) == nil
+}
+
+// ExecuteErr executes the given script and returns the reason evaluation
+// failed, if any. Unless ScriptVerifyCleanStack is set, only the top stack
+// value needs to be truthy; with it, exactly one value must remain (BIP62
+// rule 6).
+func (s *Stack) ExecuteErr(script []string) error {
 	if err := s.execute(script); err != nil {
-		return false
+		return err
 	}
 
-	if len(s.values) != 1 {
-		return false
+	if len(s.values) == 0 {
+		return newError(ErrVerifyFailed, "script left nothing on the stack")
 	}
 
-	if s.values[0] == "0" {
-		return false
+	if s.flags.HasFlag(ScriptVerifyCleanStack) && len(s.values) != 1 {
+		return newError(ErrCleanStack, "script must leave exactly one value on the stack")
 	}
 
-	return true
+	if s.values[len(s.values)-1] == "0" {
+		return newError(ErrVerifyFailed, "script evaluated to false")
+	}
+
+	return nil
 }
 
 // ExecuteUnlock executes an unlock script and returns the outcome.
 func (s *Stack) ExecuteUnlock(script []string) bool {
+	return s.ExecuteUnlockErr(script) == nil
+}
+
+// ExecuteUnlockErr executes an unlock script and returns the reason
+// evaluation failed, if any.
+func (s *Stack) ExecuteUnlockErr(script []string) error {
 	if err := s.execute(script); err != nil {
-		return false
+		return err
 	}
 
 	// If the stack contains operators, this is considered invalid.
 	for _, sval := range s.values {
 		if isOpCode(sval) {
-			return false
+			return newError(ErrMalformedPush, "unlock script left an opcode on the stack")
 		}
 	}
 
 	if len(s.values) == 0 {
-		return false
+		return newError(ErrStackUnderflow, "unlock script left nothing on the stack")
 	}
 
-	return true
+	return nil
 }
 
 func isOpCode(val string) bool {
 	return len(val) > 3 && val[:3] == "OP_"
 }
 
+// hash160 computes the RIPEMD160(SHA256(data)) digest used by OP_HASH160
+// and the P2SH (BIP16) redeem script check.
+func hash160(data []byte) []byte {
+	h := sha256.Sum256(data)
+
+	r := ripemd160.New()
+	r.Write(h[:])
+
+	return r.Sum(nil)
+}
+
+// isMinimallyEncoded reports whether val, if it's a plain base-10 numeric
+// literal, uses the shortest possible encoding: no leading zeroes and no
+// "-0". Values that aren't plain numeric literals (hex strings, ASCII data,
+// ...) are always considered minimal.
+func isMinimallyEncoded(val string) bool {
+	digits := strings.TrimPrefix(val, "-")
+	if digits == "" {
+		return true
+	}
+
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			return true
+		}
+	}
+
+	if digits == "0" {
+		return val == "0"
+	}
+
+	return digits[0] != '0'
+}
+
 // Apply the given opcode.
 var ops = map[string]Operation{
 	// Pushing values to the stack.
@@ -145,19 +343,19 @@ var ops = map[string]Operation{
 	// Conditional statements.
 	"OP_VERIFY": func(s *Stack) error {
 		if s.Size() < 1 {
-			return errors.New("OP_EQUALVERIFY requires a value on the stack")
+			return newError(ErrStackUnderflow, "OP_VERIFY requires a value on the stack")
 		}
 
 		v := s.Pop()
 
 		if v != "1" {
-			return errors.New("evaluated to false")
+			return newError(ErrVerifyFailed, "evaluated to false")
 		}
 
 		return nil
 	},
 	"OP_RETURN": func(s *Stack) error {
-		return errors.New("OP_RETURN halts execution")
+		return newError(ErrEarlyReturn, "OP_RETURN halts execution")
 	},
 
 	// Stack operations.
@@ -183,7 +381,7 @@ var ops = map[string]Operation{
 	// Binary arithmetic and conditionals.
 	"OP_EQUAL": func(s *Stack) error {
 		if s.Size() < 2 {
-			return errors.New("OP_EQUAL requires two values on the stack")
+			return newError(ErrStackUnderflow, "OP_EQUAL requires two values on the stack")
 		}
 
 		v1 := s.Pop()
@@ -199,14 +397,14 @@ var ops = map[string]Operation{
 	},
 	"OP_EQUALVERIFY": func(s *Stack) error {
 		if s.Size() < 2 {
-			return errors.New("OP_EQUALVERIFY requires two values on the stack")
+			return newError(ErrStackUnderflow, "OP_EQUALVERIFY requires two values on the stack")
 		}
 
 		v1 := s.Pop()
 		v2 := s.Pop()
 
 		if v1 != v2 {
-			return errors.New("evaluated to false")
+			return newError(ErrVerifyFailed, "evaluated to false")
 		}
 
 		return nil
@@ -215,17 +413,17 @@ var ops = map[string]Operation{
 	// Numeric operators.
 	"OP_ADD": func(s *Stack) error {
 		if s.Size() < 2 {
-			return errors.New("OP_ADD requires two values on the stack")
+			return newError(ErrStackUnderflow, "OP_ADD requires two values on the stack")
 		}
 
 		v1, err := s.PopInt()
 		if err != nil {
-			return errors.Wrap(err, "stack value isn't a number")
+			return newErrorf(ErrMalformedPush, "stack value isn't a number: %v", err)
 		}
 
 		v2, err := s.PopInt()
 		if err != nil {
-			return errors.Wrap(err, "stack value isn't a number")
+			return newErrorf(ErrMalformedPush, "stack value isn't a number: %v", err)
 		}
 
 		s.Push(strconv.FormatInt(v1+v2, 10))
@@ -234,7 +432,7 @@ var ops = map[string]Operation{
 	},
 	"OP_NOT": func(s *Stack) error {
 		if s.Size() < 1 {
-			return errors.New("OP_NOT requires a value on the stack")
+			return newError(ErrStackUnderflow, "OP_NOT requires a value on the stack")
 		}
 
 		v := s.Pop()
@@ -248,17 +446,17 @@ var ops = map[string]Operation{
 	},
 	"OP_SUB": func(s *Stack) error {
 		if s.Size() < 2 {
-			return errors.New("OP_SUB requires two values on the stack")
+			return newError(ErrStackUnderflow, "OP_SUB requires two values on the stack")
 		}
 
 		v1, err := s.PopInt()
 		if err != nil {
-			return errors.Wrap(err, "stack value isn't a number")
+			return newErrorf(ErrMalformedPush, "stack value isn't a number: %v", err)
 		}
 
 		v2, err := s.PopInt()
 		if err != nil {
-			return errors.Wrap(err, "stack value isn't a number")
+			return newErrorf(ErrMalformedPush, "stack value isn't a number: %v", err)
 		}
 
 		s.Push(strconv.FormatInt(v2-v1, 10))
@@ -267,7 +465,7 @@ var ops = map[string]Operation{
 	},
 	"OP_BOOLAND": func(s *Stack) error {
 		if s.Size() < 2 {
-			return errors.New("OP_BOOLAND requires two values on the stack")
+			return newError(ErrStackUnderflow, "OP_BOOLAND requires two values on the stack")
 		}
 
 		b1 := s.Pop()
@@ -283,7 +481,7 @@ var ops = map[string]Operation{
 	},
 	"OP_BOOLOR": func(s *Stack) error {
 		if s.Size() < 2 {
-			return errors.New("OP_BOOLOR requires two values on the stack")
+			return newError(ErrStackUnderflow, "OP_BOOLOR requires two values on the stack")
 		}
 
 		b1 := s.Pop()
@@ -301,27 +499,28 @@ var ops = map[string]Operation{
 	// Cryptographic operations.
 	"OP_RIPEMD160": func(s *Stack) error {
 		if s.Size() < 1 {
-			return errors.New("OP_RIPEMD160 requires a value on the stack")
+			return newError(ErrStackUnderflow, "OP_RIPEMD160 requires a value on the stack")
 		}
 
 		v, err := hex.DecodeString(s.Pop())
 		if err != nil {
-			return errors.Wrap(err, "stack value should be a hex string")
+			return newErrorf(ErrMalformedPush, "stack value should be a hex string: %v", err)
 		}
 
-		vv := hex.EncodeToString(ripemd160.New().Sum(v))
-		s.Push(vv)
+		r := ripemd160.New()
+		r.Write(v)
+		s.Push(hex.EncodeToString(r.Sum(nil)))
 
 		return nil
 	},
 	"OP_SHA1": func(s *Stack) error {
 		if s.Size() < 1 {
-			return errors.New("OP_SHA1 requires a value on the stack")
+			return newError(ErrStackUnderflow, "OP_SHA1 requires a value on the stack")
 		}
 
 		v, err := hex.DecodeString(s.Pop())
 		if err != nil {
-			return errors.Wrap(err, "stack value should be a hex string")
+			return newErrorf(ErrMalformedPush, "stack value should be a hex string: %v", err)
 		}
 
 		vv := sha1.Sum(v)
@@ -331,12 +530,12 @@ var ops = map[string]Operation{
 	},
 	"OP_SHA256": func(s *Stack) error {
 		if s.Size() < 1 {
-			return errors.New("OP_SHA256 requires a value on the stack")
+			return newError(ErrStackUnderflow, "OP_SHA256 requires a value on the stack")
 		}
 
 		v, err := hex.DecodeString(s.Pop())
 		if err != nil {
-			return errors.Wrap(err, "stack value should be a hex string")
+			return newErrorf(ErrMalformedPush, "stack value should be a hex string: %v", err)
 		}
 
 		vv := sha256.Sum256(v)
@@ -346,28 +545,26 @@ var ops = map[string]Operation{
 	},
 	"OP_HASH160": func(s *Stack) error {
 		if s.Size() < 1 {
-			return errors.New("OP_HASH160 requires a value on the stack")
+			return newError(ErrStackUnderflow, "OP_HASH160 requires a value on the stack")
 		}
 
 		v, err := hex.DecodeString(s.Pop())
 		if err != nil {
-			return errors.Wrap(err, "stack value should be a hex string")
+			return newErrorf(ErrMalformedPush, "stack value should be a hex string: %v", err)
 		}
 
-		h1 := sha256.Sum256(v)
-		h2 := ripemd160.New().Sum(h1[:])
-		s.Push(hex.EncodeToString(h2))
+		s.Push(hex.EncodeToString(hash160(v)))
 
 		return nil
 	},
 	"OP_HASH256": func(s *Stack) error {
 		if s.Size() < 1 {
-			return errors.New("OP_HASH256 requires a value on the stack")
+			return newError(ErrStackUnderflow, "OP_HASH256 requires a value on the stack")
 		}
 
 		v, err := hex.DecodeString(s.Pop())
 		if err != nil {
-			return errors.Wrap(err, "stack value should be a hex string")
+			return newErrorf(ErrMalformedPush, "stack value should be a hex string: %v", err)
 		}
 
 		h1 := sha256.Sum256(v)
@@ -378,7 +575,7 @@ var ops = map[string]Operation{
 	},
 	"OP_CHECKSIG": func(s *Stack) error {
 		if s.Size() < 2 {
-			return errors.New("OP_CHECKSIG requires two values on the stack")
+			return newError(ErrStackUnderflow, "OP_CHECKSIG requires two values on the stack")
 		}
 
 		ok, err := checkSig(s)
@@ -392,12 +589,12 @@ var ops = map[string]Operation{
 	},
 	"OP_CHECKSIGVERIFY": func(s *Stack) error {
 		if s.Size() < 2 {
-			return errors.New("OP_CHECKSIGVERIFY requires two values on the stack")
+			return newError(ErrStackUnderflow, "OP_CHECKSIGVERIFY requires two values on the stack")
 		}
 
 		ok, err := checkSig(s)
 		if !ok {
-			return errors.New("invalid signature")
+			return newError(ErrInvalidSignature, "invalid signature")
 		}
 
 		return err
@@ -415,94 +612,214 @@ var ops = map[string]Operation{
 	"OP_CHECKMULTISIGVERIFY": func(s *Stack) error {
 		ok, err := checkMultiSig(s)
 		if !ok {
-			return errors.New("invalid signature")
+			return newError(ErrInvalidSignature, "invalid signature")
 		}
 
 		return err
 	},
+
+	// OP_CHECKLOCKTIMEVERIFY (BIP65) and OP_CHECKSEQUENCEVERIFY (BIP112)
+	// repurpose what used to be OP_NOP2/OP_NOP3.
+	"OP_CHECKLOCKTIMEVERIFY": checkLockTimeVerify,
+	"OP_CHECKSEQUENCEVERIFY": checkSequenceVerify,
+
+	// Reserved for future upgrades: no-ops unless discouraged.
+	"OP_NOP1":  upgradableNop,
+	"OP_NOP4":  upgradableNop,
+	"OP_NOP5":  upgradableNop,
+	"OP_NOP6":  upgradableNop,
+	"OP_NOP7":  upgradableNop,
+	"OP_NOP8":  upgradableNop,
+	"OP_NOP9":  upgradableNop,
+	"OP_NOP10": upgradableNop,
+}
+
+// upgradableNop implements the still-reserved OP_NOP opcodes: they do
+// nothing, unless ScriptDiscourageUpgradableNops is set, in which case a
+// script using them is rejected since a future soft fork may give them
+// meaning (as BIP65 and BIP112 did for OP_NOP2 and OP_NOP3).
+func upgradableNop(s *Stack) error {
+	if s.flags.HasFlag(ScriptDiscourageUpgradableNops) {
+		return newError(ErrDiscourageUpgradableNops, "reserved OP_NOP opcodes are reserved for future upgrades")
+	}
+
+	return nil
+}
+
+// lockTimeThreshold is the boundary below which a locktime or sequence value
+// is interpreted as a block height, and above which it's a Unix timestamp.
+const lockTimeThreshold = 500000000
+
+// checkLockTimeVerify implements OP_CHECKLOCKTIMEVERIFY (BIP65): it peeks
+// the top stack item and requires the transaction's locktime to satisfy it,
+// without consuming it.
+func checkLockTimeVerify(s *Stack) error {
+	if s.Size() < 1 {
+		return newError(ErrStackUnderflow, "OP_CHECKLOCKTIMEVERIFY requires a value on the stack")
+	}
+
+	lockTime, err := s.PeekInt()
+	if err != nil {
+		return newErrorf(ErrMalformedPush, "stack value should be a scriptnum: %v", err)
+	}
+
+	if lockTime < 0 {
+		return newError(ErrNegativeLockTime, "OP_CHECKLOCKTIMEVERIFY requires a non-negative locktime")
+	}
+
+	if (lockTime < lockTimeThreshold) != (int64(s.txLockTime) < lockTimeThreshold) {
+		return newError(ErrUnsatisfiedLockTime, "OP_CHECKLOCKTIMEVERIFY can't compare a height-based and a time-based locktime")
+	}
+
+	if int64(s.txLockTime) < lockTime {
+		return newError(ErrUnsatisfiedLockTime, "transaction locktime is below the required value")
+	}
+
+	if s.inputSequence == 0xffffffff {
+		return newError(ErrUnsatisfiedLockTime, "locktime is disabled by a final input sequence")
+	}
+
+	return nil
+}
+
+// Bit flags defined by BIP112 for the value OP_CHECKSEQUENCEVERIFY checks,
+// mirroring the ones carried by CTxIn::nSequence.
+const (
+	sequenceLockTimeDisableFlag = 1 << 31
+	sequenceLockTimeTypeFlag    = 1 << 22
+	sequenceLockTimeMask        = 0x0000ffff
+)
+
+// checkSequenceVerify implements OP_CHECKSEQUENCEVERIFY (BIP112): it peeks
+// the top stack item and requires the input's sequence to satisfy it,
+// without consuming it.
+func checkSequenceVerify(s *Stack) error {
+	if s.Size() < 1 {
+		return newError(ErrStackUnderflow, "OP_CHECKSEQUENCEVERIFY requires a value on the stack")
+	}
+
+	sequence, err := s.PeekInt()
+	if err != nil {
+		return newErrorf(ErrMalformedPush, "stack value should be a scriptnum: %v", err)
+	}
+
+	if sequence < 0 {
+		return newError(ErrNegativeLockTime, "OP_CHECKSEQUENCEVERIFY requires a non-negative sequence")
+	}
+
+	if sequence&sequenceLockTimeDisableFlag != 0 {
+		return nil
+	}
+
+	if s.txVersion < 2 {
+		return newError(ErrUnsatisfiedLockTime, "OP_CHECKSEQUENCEVERIFY requires a version 2 or higher transaction")
+	}
+
+	if int64(s.inputSequence)&sequenceLockTimeDisableFlag != 0 {
+		return newError(ErrUnsatisfiedLockTime, "OP_CHECKSEQUENCEVERIFY is disabled by the input sequence")
+	}
+
+	if sequence&sequenceLockTimeTypeFlag != int64(s.inputSequence)&sequenceLockTimeTypeFlag {
+		return newError(ErrUnsatisfiedLockTime, "OP_CHECKSEQUENCEVERIFY can't compare a height-based and a time-based sequence")
+	}
+
+	if int64(s.inputSequence)&sequenceLockTimeMask < sequence&sequenceLockTimeMask {
+		return newError(ErrUnsatisfiedLockTime, "input sequence is below the required value")
+	}
+
+	return nil
 }
 
 func checkSig(s *Stack) (bool, error) {
 	pkBytes, err := hex.DecodeString(s.Pop())
 	if err != nil {
-		return false, errors.Wrap(err, "public key should be a hex string")
+		return false, newErrorf(ErrMalformedPush, "public key should be a hex string: %v", err)
 	}
 
 	pubKey, err := btcec.ParsePubKey(pkBytes, btcec.S256())
 	if err != nil {
-		return false, errors.Wrap(err, "could not parse public key")
+		return false, newErrorf(ErrInvalidSignature, "could not parse public key: %v", err)
 	}
 
 	sigBytes, err := hex.DecodeString(s.Pop())
 	if err != nil {
-		return false, errors.Wrap(err, "signature should be a hex string")
+		return false, newErrorf(ErrMalformedPush, "signature should be a hex string: %v", err)
 	}
 
 	sig, err := btcec.ParseSignature(sigBytes, btcec.S256())
 	if err != nil {
-		return false, errors.Wrap(err, "could not parse signature")
+		return false, newErrorf(ErrInvalidSignature, "could not parse signature: %v", err)
 	}
 
 	return sig.Verify(s.tx, pubKey), nil
 }
 
 func checkMultiSig(s *Stack) (bool, error) {
-	// The original implementation has a bug and pops one more element than
-	// needed.
-	// I'm choosing not to implement compatibility with that bug since this is
-	// only meant to be a learning experiment.
+	// The original Bitcoin client has a bug and pops one more element than
+	// needed: every CHECKMULTISIG script must push an extra dummy value
+	// that gets consumed below. ScriptStrictMultiSig (NULLDUMMY) further
+	// requires that dummy to be empty.
 	if s.Size() < 1 {
-		return false, errors.New("OP_CHECKMULTISIG requires a value for N on the stack")
+		return false, newError(ErrStackUnderflow, "OP_CHECKMULTISIG requires a value for N on the stack")
 	}
 
 	n, err := s.PopInt()
 	if err != nil {
-		return false, errors.Wrap(err, "could not parse value of N")
+		return false, newErrorf(ErrMalformedPush, "could not parse value of N: %v", err)
 	}
 
 	if s.Size() < int(n) {
-		return false, fmt.Errorf("OP_CHECKMULTISIG needs %d public keys", n)
+		return false, newErrorf(ErrStackUnderflow, "OP_CHECKMULTISIG needs %d public keys", n)
 	}
 
 	pubKeys := make([]*btcec.PublicKey, n)
 	for i := 0; i < int(n); i++ {
 		pkBytes, err := hex.DecodeString(s.Pop())
 		if err != nil {
-			return false, errors.Wrap(err, "public key should be a hex string")
+			return false, newErrorf(ErrMalformedPush, "public key should be a hex string: %v", err)
 		}
 
 		pubKeys[i], err = btcec.ParsePubKey(pkBytes, btcec.S256())
 		if err != nil {
-			return false, errors.Wrap(err, "could not parse public key")
+			return false, newErrorf(ErrInvalidSignature, "could not parse public key: %v", err)
 		}
 	}
 
 	if s.Size() < 1 {
-		return false, errors.New("OP_CHECKMULTISIG requires a value for M on the stack")
+		return false, newError(ErrStackUnderflow, "OP_CHECKMULTISIG requires a value for M on the stack")
 	}
 
 	m, err := s.PopInt()
 	if err != nil {
-		return false, errors.Wrap(err, "could not parse value of M")
+		return false, newErrorf(ErrMalformedPush, "could not parse value of M: %v", err)
 	}
 
 	if s.Size() < int(m) {
-		return false, fmt.Errorf("OP_CHECKMULTISIG needs %d signatures", m)
+		return false, newErrorf(ErrStackUnderflow, "OP_CHECKMULTISIG needs %d signatures", m)
 	}
 
 	sigs := make([]*btcec.Signature, m)
 	for i := 0; i < int(m); i++ {
 		sigBytes, err := hex.DecodeString(s.Pop())
 		if err != nil {
-			return false, errors.Wrap(err, "signature should be a hex string")
+			return false, newErrorf(ErrMalformedPush, "signature should be a hex string: %v", err)
 		}
 
 		sigs[i], err = btcec.ParseSignature(sigBytes, btcec.S256())
 		if err != nil {
-			return false, errors.Wrap(err, "could not parse signature")
+			return false, newErrorf(ErrInvalidSignature, "could not parse signature: %v", err)
 		}
 	}
 
+	if s.Size() < 1 {
+		return false, newError(ErrStackUnderflow, "OP_CHECKMULTISIG requires a dummy element on the stack")
+	}
+
+	dummy := s.Pop()
+	if s.flags.HasFlag(ScriptStrictMultiSig) && dummy != "0" {
+		return false, newError(ErrNullDummy, "OP_CHECKMULTISIG dummy element must be empty")
+	}
+
 	// Very naive, can be optimized.
 	for _, sig := range sigs {
 		valid := false
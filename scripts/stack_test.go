@@ -36,7 +36,7 @@ func TestStack(t *testing.T) {
 				nil,
 				false,
 			}, {
-				"too many stack results",
+				"multiple stack results with a truthy top value",
 				[]string{
 					"1",
 					"2",
@@ -47,7 +47,7 @@ func TestStack(t *testing.T) {
 					"3",
 					"3",
 				},
-				false,
+				true,
 			}, {
 				"unsatisfied equal",
 				[]string{
@@ -81,6 +81,78 @@ func TestStack(t *testing.T) {
 					"1",
 				},
 				true,
+			}, {
+				"taken if branch",
+				[]string{
+					"1",
+					"OP_IF",
+					"OP_TRUE",
+					"OP_ELSE",
+					"OP_FALSE",
+					"OP_ENDIF",
+				},
+				[]string{
+					"1",
+				},
+				true,
+			}, {
+				"taken else branch",
+				[]string{
+					"0",
+					"OP_IF",
+					"OP_TRUE",
+					"OP_ELSE",
+					"OP_FALSE",
+					"OP_ENDIF",
+				},
+				[]string{
+					"0",
+				},
+				false,
+			}, {
+				"nested branches, HTLC-style",
+				[]string{
+					"1",
+					"OP_IF",
+					"1",
+					"OP_IF",
+					"OP_TRUE",
+					"OP_ELSE",
+					"OP_FALSE",
+					"OP_ENDIF",
+					"OP_ELSE",
+					"OP_FALSE",
+					"OP_ENDIF",
+				},
+				[]string{
+					"1",
+				},
+				true,
+			}, {
+				"notif takes the false branch",
+				[]string{
+					"1",
+					"OP_NOTIF",
+					"OP_TRUE",
+					"OP_ELSE",
+					"OP_FALSE",
+					"OP_ENDIF",
+				},
+				[]string{
+					"0",
+				},
+				false,
+			}, {
+				"unbalanced conditional",
+				[]string{
+					"1",
+					"OP_IF",
+					"OP_TRUE",
+				},
+				[]string{
+					"1",
+				},
+				false,
 			}}
 
 		for _, tt := range testCases {
@@ -158,4 +230,135 @@ func TestStack(t *testing.T) {
 			})
 		}
 	})
+
+	t.Run("ScriptFlags", func(t *testing.T) {
+		type flagTestCase struct {
+			name     string
+			script   []string
+			flags    scripts.ScriptFlags
+			expected bool
+		}
+
+		flagTestCases := []flagTestCase{
+			{
+				"multiple results rejected with ScriptVerifyCleanStack",
+				[]string{"1", "2", "OP_ADD", "OP_DUP"},
+				scripts.ScriptVerifyCleanStack,
+				false,
+			}, {
+				"multiple results allowed without ScriptVerifyCleanStack",
+				[]string{"1", "2", "OP_ADD", "OP_DUP"},
+				0,
+				true,
+			}, {
+				"non-minimal push rejected with ScriptVerifyMinimalData",
+				[]string{"007", "OP_TRUE"},
+				scripts.ScriptVerifyMinimalData,
+				false,
+			}, {
+				"non-minimal push allowed without ScriptVerifyMinimalData",
+				[]string{"007", "OP_TRUE"},
+				0,
+				true,
+			}, {
+				"upgradable nop rejected with ScriptDiscourageUpgradableNops",
+				[]string{"OP_NOP5", "OP_TRUE"},
+				scripts.ScriptDiscourageUpgradableNops,
+				false,
+			}, {
+				"upgradable nop allowed without ScriptDiscourageUpgradableNops",
+				[]string{"OP_NOP5", "OP_TRUE"},
+				0,
+				true,
+			},
+		}
+
+		for _, tt := range flagTestCases {
+			t.Run(tt.name, func(t *testing.T) {
+				s := scripts.InitStack().WithFlags(tt.flags)
+				assert.Equal(t, tt.expected, s.Execute(tt.script))
+			})
+		}
+	})
+
+	t.Run("OP_CHECKLOCKTIMEVERIFY and OP_CHECKSEQUENCEVERIFY", func(t *testing.T) {
+		type lockTimeTestCase struct {
+			name          string
+			script        []string
+			txLockTime    uint32
+			inputSequence uint32
+			txVersion     int32
+			expected      bool
+		}
+
+		testCases := []lockTimeTestCase{
+			{
+				"height lock satisfied",
+				[]string{"500", "OP_CHECKLOCKTIMEVERIFY"},
+				600, 0, 0,
+				true,
+			}, {
+				"height lock not yet reached",
+				[]string{"500", "OP_CHECKLOCKTIMEVERIFY"},
+				400, 0, 0,
+				false,
+			}, {
+				"time lock satisfied",
+				[]string{"500000001", "OP_CHECKLOCKTIMEVERIFY"},
+				500000002, 0, 0,
+				true,
+			}, {
+				"can't compare a height lock against a time-based tx locktime",
+				[]string{"500", "OP_CHECKLOCKTIMEVERIFY"},
+				500000001, 0, 0,
+				false,
+			}, {
+				"disabled by a final input sequence",
+				[]string{"500", "OP_CHECKLOCKTIMEVERIFY"},
+				600, 0xffffffff, 0,
+				false,
+			}, {
+				"negative locktime is rejected",
+				[]string{"-1", "OP_CHECKLOCKTIMEVERIFY"},
+				600, 0, 0,
+				false,
+			}, {
+				"relative height lock satisfied",
+				[]string{"5", "OP_CHECKSEQUENCEVERIFY"},
+				0, 10, 2,
+				true,
+			}, {
+				"relative height lock not yet reached",
+				[]string{"5", "OP_CHECKSEQUENCEVERIFY"},
+				0, 3, 2,
+				false,
+			}, {
+				"rejected on a version 1 transaction",
+				[]string{"5", "OP_CHECKSEQUENCEVERIFY"},
+				0, 10, 1,
+				false,
+			}, {
+				"disable flag on the stack value is a no-op",
+				[]string{"2147483648", "OP_CHECKSEQUENCEVERIFY"},
+				0, 0, 1,
+				true,
+			}, {
+				"can't compare a height-based stack value against a time-based sequence",
+				[]string{"5", "OP_CHECKSEQUENCEVERIFY"},
+				0, 1<<22 | 10, 2,
+				false,
+			},
+		}
+
+		for _, tt := range testCases {
+			t.Run(tt.name, func(t *testing.T) {
+				s := scripts.InitStack().
+					WithTxLockTime(tt.txLockTime).
+					WithInputSequence(tt.inputSequence).
+					WithTxVersion(tt.txVersion)
+
+				assert.Equal(t, tt.expected, s.Execute(tt.script))
+			})
+		}
+	})
 }